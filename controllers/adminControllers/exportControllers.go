@@ -0,0 +1,317 @@
+package adminControllers
+
+import (
+	"doctorAppointment/configuration"
+	"doctorAppointment/middlewares"
+	"doctorAppointment/models"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// ExportFilters are the query filters shared by ExportAppointments and ExportInvoices.
+type ExportFilters struct {
+	DateFrom       string `json:"date_from"`
+	DateTo         string `json:"date_to"`
+	DoctorID       string `json:"doctor_id"`
+	Specialization string `json:"specialization"`
+	PaymentStatus  string `json:"payment_status"`
+	HospitalID     string `json:"hospital_id"`
+	Async          bool   `json:"async"`
+}
+
+// ExportAppointments streams an .xlsx of appointments matching the given filters,
+// with a summary sheet of totals per doctor and per day. With "async": true the
+// file is generated and saved immediately but a download token is returned instead
+// of the file body, for callers that would rather poll GET /admin/export/:token/download.
+func ExportAppointments(c *gin.Context) {
+	var filters ExportFilters
+	if err := c.BindJSON(&filters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	orgID, scoped := middlewares.OrgIDFromContext(c)
+
+	var appointments []models.Appointment
+	if err := appointmentExportQuery(filters).Scopes(orgDoctorScope(orgID, scoped)).Find(&appointments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch appointments"})
+		return
+	}
+
+	workbook := buildAppointmentsWorkbook(appointments)
+	finishExport(c, workbook, "appointments", filters.Async, orgID, scoped)
+}
+
+// ExportInvoices streams an .xlsx of invoices matching the given filters, with a
+// summary sheet of revenue totals per doctor and per day. Supports the same
+// async/token flow as ExportAppointments.
+func ExportInvoices(c *gin.Context) {
+	var filters ExportFilters
+	if err := c.BindJSON(&filters); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	orgID, scoped := middlewares.OrgIDFromContext(c)
+
+	var invoices []models.Invoice
+	if err := invoiceExportQuery(filters).Scopes(orgDoctorScope(orgID, scoped)).Find(&invoices).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invoices"})
+		return
+	}
+
+	workbook := buildInvoicesWorkbook(invoices)
+	finishExport(c, workbook, "invoices", filters.Async, orgID, scoped)
+}
+
+// GetExportDownload serves a previously generated export file by its token, after
+// verifying the caller's organization matches the one the export was generated for.
+func GetExportDownload(c *gin.Context) {
+	token := c.Param("token")
+
+	var job models.ExportJob
+	if err := configuration.DB.Where("token = ?", token).First(&job).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Export not found"})
+		return
+	}
+
+	callerOrgID, callerScoped := middlewares.OrgIDFromContext(c)
+	if callerScoped && (!job.OrgScoped || job.OrgID != callerOrgID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You may only download your own organization's exports"})
+		return
+	}
+
+	if job.Status != "ready" {
+		c.JSON(http.StatusAccepted, gin.H{"Status": job.Status})
+		return
+	}
+
+	c.FileAttachment(job.FilePath, filepath.Base(job.FilePath))
+}
+
+// orgDoctorScope narrows an appointment/invoice export query to rows whose doctor
+// belongs to orgID, the same boundary GetPatientTimeline uses. A no-op for an
+// unscoped (super_admin) caller.
+func orgDoctorScope(orgID int, scoped bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if !scoped {
+			return db
+		}
+		return db.Where("doctor_id IN (?)", orgDoctorIDsSubquery(orgID))
+	}
+}
+
+// finishExport either streams workbook directly or saves it to disk behind a
+// download token, depending on async. orgID/scoped are stamped onto the saved
+// ExportJob so GetExportDownload can verify the downloader owns it.
+func finishExport(c *gin.Context, workbook *excelize.File, kind string, async bool, orgID int, scoped bool) {
+	if !async {
+		streamWorkbook(c, workbook, kind+".xlsx")
+		return
+	}
+
+	job, err := saveExportFile(workbook, kind, orgID, scoped)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save export"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"Status": "Success",
+		"token":  job.Token,
+	})
+}
+
+// streamWorkbook writes workbook directly to the response as an .xlsx download.
+func streamWorkbook(c *gin.Context, workbook *excelize.File, filename string) {
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	if err := workbook.Write(c.Writer); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to stream export"})
+	}
+}
+
+// saveExportFile writes workbook to a temp file and records it as a downloadable
+// ExportJob, scoped to orgID so only that organization's caller can download it.
+func saveExportFile(workbook *excelize.File, kind string, orgID int, scoped bool) (*models.ExportJob, error) {
+	token := uuid.NewString()
+	path := filepath.Join(os.TempDir(), fmt.Sprintf("%s-%s.xlsx", kind, token))
+	if err := workbook.SaveAs(path); err != nil {
+		return nil, err
+	}
+
+	job := models.ExportJob{
+		Token:     token,
+		Kind:      kind,
+		Status:    "ready",
+		FilePath:  path,
+		ExpiresAt: time.Now().Add(24 * time.Hour),
+		OrgID:     orgID,
+		OrgScoped: scoped,
+	}
+	if err := configuration.DB.Create(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// appointmentExportQuery builds the filtered Appointment query for ExportAppointments.
+func appointmentExportQuery(filters ExportFilters) *gorm.DB {
+	query := configuration.DB.Model(&models.Appointment{})
+	if filters.DateFrom != "" && filters.DateTo != "" {
+		query = query.Where("appointment_date BETWEEN ? AND ?", filters.DateFrom, filters.DateTo)
+	}
+	if filters.DoctorID != "" {
+		query = query.Where("doctor_id = ?", filters.DoctorID)
+	}
+	if filters.PaymentStatus != "" {
+		query = query.Where("payment_status = ?", filters.PaymentStatus)
+	}
+	if doctorIDs, ok := doctorIDsForFilters(filters); ok {
+		query = query.Where("doctor_id IN ?", doctorIDs)
+	}
+	return query
+}
+
+// invoiceExportQuery builds the filtered Invoice query for ExportInvoices.
+func invoiceExportQuery(filters ExportFilters) *gorm.DB {
+	query := configuration.DB.Model(&models.Invoice{})
+	if filters.DateFrom != "" && filters.DateTo != "" {
+		query = query.Where("payment_due_date BETWEEN ? AND ?", filters.DateFrom, filters.DateTo)
+	}
+	if filters.DoctorID != "" {
+		query = query.Where("doctor_id = ?", filters.DoctorID)
+	}
+	if filters.PaymentStatus != "" {
+		query = query.Where("payment_status = ?", filters.PaymentStatus)
+	}
+	if doctorIDs, ok := doctorIDsForFilters(filters); ok {
+		query = query.Where("doctor_id IN ?", doctorIDs)
+	}
+	return query
+}
+
+// doctorIDsForFilters resolves specialization/hospital_id filters into a concrete
+// list of doctor IDs, since Appointment and Invoice don't carry those columns directly.
+func doctorIDsForFilters(filters ExportFilters) ([]int, bool) {
+	if filters.Specialization == "" && filters.HospitalID == "" {
+		return nil, false
+	}
+
+	doctorQuery := configuration.DB.Model(&models.Doctor{})
+	if filters.Specialization != "" {
+		doctorQuery = doctorQuery.Where("specialization = ?", filters.Specialization)
+	}
+	if filters.HospitalID != "" {
+		doctorQuery = doctorQuery.Where("hospital_id = ?", filters.HospitalID)
+	}
+
+	var doctorIDs []int
+	doctorQuery.Pluck("doctor_id", &doctorIDs)
+	return doctorIDs, true
+}
+
+// buildAppointmentsWorkbook renders appointments into an .xlsx with a summary sheet
+// of appointment counts per doctor and per day.
+func buildAppointmentsWorkbook(appointments []models.Appointment) *excelize.File {
+	f := excelize.NewFile()
+	sheet := "Appointments"
+	f.SetSheetName("Sheet1", sheet)
+
+	headers := []string{"Appointment ID", "Doctor ID", "Patient ID", "Date", "Time Slot", "Booking Status", "Payment Status"}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, header)
+	}
+
+	countByDoctor := make(map[int]float64)
+	countByDay := make(map[string]float64)
+	for i, appointment := range appointments {
+		row := i + 2
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), appointment.AppointmentID)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), appointment.DoctorID)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), appointment.PatientID)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), appointment.AppointmentDate.Format("2006-01-02"))
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), appointment.AppointmentTimeSlot)
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), appointment.BookingStatus)
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), appointment.PaymentStatus)
+
+		countByDoctor[appointment.DoctorID]++
+		countByDay[appointment.AppointmentDate.Format("2006-01-02")]++
+	}
+
+	addSummarySheet(f, "Appointment Count", countByDoctor, countByDay)
+	return f
+}
+
+// buildInvoicesWorkbook renders invoices into an .xlsx with a summary sheet of
+// revenue totals per doctor and per day.
+func buildInvoicesWorkbook(invoices []models.Invoice) *excelize.File {
+	f := excelize.NewFile()
+	sheet := "Invoices"
+	f.SetSheetName("Sheet1", sheet)
+
+	headers := []string{"Invoice ID", "Doctor ID", "Patient ID", "Appointment ID", "Total Amount", "Payment Method", "Payment Status", "Payment Due Date"}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, header)
+	}
+
+	revenueByDoctor := make(map[int]float64)
+	revenueByDay := make(map[string]float64)
+	for i, invoice := range invoices {
+		row := i + 2
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), invoice.ID)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), invoice.DoctorID)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), invoice.PatientID)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), invoice.AppointmentID)
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), invoice.TotalAmount)
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), invoice.PaymentMethod)
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), invoice.PaymentStatus)
+		f.SetCellValue(sheet, fmt.Sprintf("H%d", row), invoice.PaymentDueDate.Format("2006-01-02"))
+
+		revenueByDoctor[int(invoice.DoctorID)] += invoice.TotalAmount
+		revenueByDay[invoice.PaymentDueDate.Format("2006-01-02")] += invoice.TotalAmount
+	}
+
+	addSummarySheet(f, "Revenue", revenueByDoctor, revenueByDay)
+	return f
+}
+
+// addSummarySheet writes a "Summary" sheet with two tables (by doctor, by day) for
+// the given metric, plus a grand total.
+func addSummarySheet(f *excelize.File, metricLabel string, byDoctor map[int]float64, byDay map[string]float64) {
+	sheet := "Summary"
+	f.NewSheet(sheet)
+
+	row := 1
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", row), "Doctor ID")
+	f.SetCellValue(sheet, fmt.Sprintf("B%d", row), metricLabel)
+	row++
+	for doctorID, total := range byDoctor {
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), doctorID)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), total)
+		row++
+	}
+
+	row++
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", row), "Date")
+	f.SetCellValue(sheet, fmt.Sprintf("B%d", row), metricLabel)
+	row++
+	grandTotal := 0.0
+	for day, total := range byDay {
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), day)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), total)
+		grandTotal += total
+		row++
+	}
+
+	row++
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", row), "Grand Total")
+	f.SetCellValue(sheet, fmt.Sprintf("B%d", row), grandTotal)
+}
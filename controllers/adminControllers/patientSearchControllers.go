@@ -0,0 +1,233 @@
+package adminControllers
+
+import (
+	"doctorAppointment/configuration"
+	"doctorAppointment/middlewares"
+	"doctorAppointment/models"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PatientSearchScope narrows the query to patients whose name or patient_id
+// matches keyword. A blank keyword is a no-op.
+func PatientSearchScope(keyword string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if keyword == "" {
+			return db
+		}
+		like := "%" + keyword + "%"
+		return db.Where("name LIKE ? OR patient_id LIKE ?", like, like)
+	}
+}
+
+// HasAppointmentsScope narrows to patients that do (or don't) have any appointment.
+func HasAppointmentsScope(has bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		withAppointments := configuration.DB.Model(&models.Appointment{}).Select("patient_id")
+		if has {
+			return db.Where("patient_id IN (?)", withAppointments)
+		}
+		return db.Where("patient_id NOT IN (?)", withAppointments)
+	}
+}
+
+// HasUnpaidInvoicesScope narrows to patients that do (or don't) have an invoice
+// whose payment_status isn't "Paid".
+func HasUnpaidInvoicesScope(has bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		unpaid := configuration.DB.Model(&models.Invoice{}).Where("payment_status != ?", "Paid").Select("patient_id")
+		if has {
+			return db.Where("patient_id IN (?)", unpaid)
+		}
+		return db.Where("patient_id NOT IN (?)", unpaid)
+	}
+}
+
+// LastVisitBetweenScope narrows to patients with an appointment date in [from, to].
+// Blank bounds are a no-op.
+func LastVisitBetweenScope(from, to string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if from == "" || to == "" {
+			return db
+		}
+		visited := configuration.DB.Model(&models.Appointment{}).
+			Where("appointment_date BETWEEN ? AND ?", from, to).
+			Select("patient_id")
+		return db.Where("patient_id IN (?)", visited)
+	}
+}
+
+// DoctorFilterScope narrows to patients who have an appointment with a specific
+// doctor and/or a doctor of a given specialization.
+func DoctorFilterScope(doctorID, specialization string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if doctorID == "" && specialization == "" {
+			return db
+		}
+		treated := configuration.DB.Model(&models.Appointment{}).Select("patient_id")
+		if doctorID != "" {
+			treated = treated.Where("doctor_id = ?", doctorID)
+		}
+		if specialization != "" {
+			specializedDoctorIDs := configuration.DB.Model(&models.Doctor{}).
+				Where("specialization = ?", specialization).
+				Select("doctor_id")
+			treated = treated.Where("doctor_id IN (?)", specializedDoctorIDs)
+		}
+		return db.Where("patient_id IN (?)", treated)
+	}
+}
+
+// ContagionTagsScope narrows to patients whose contagion/chronic_condition column
+// matches one of tags. An empty tag list is a no-op.
+func ContagionTagsScope(tags []string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(tags) == 0 {
+			return db
+		}
+		return db.Where("contagion IN ?", tags)
+	}
+}
+
+// OrgPatientScope narrows to patients who have been treated by a doctor in orgID.
+// A no-op for a super_admin caller (scoped == false), who isn't limited to one org.
+func OrgPatientScope(orgID int, scoped bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if !scoped {
+			return db
+		}
+		orgPatientIDs := configuration.DB.Model(&models.Appointment{}).Where("doctor_id IN (?)", orgDoctorIDsSubquery(orgID)).Select("patient_id")
+		return db.Where("patient_id IN (?)", orgPatientIDs)
+	}
+}
+
+// paginationParams reads page/limit query params, defaulting to page 1, limit 20.
+func paginationParams(c *gin.Context) (page, limit int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err = strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 {
+		limit = 20
+	}
+	return page, limit
+}
+
+// GetAdminPatients lists patients with keyword search, pagination, and filters
+// composed as db.Scopes(...).
+func GetAdminPatients(c *gin.Context) {
+	page, limit := paginationParams(c)
+	orgID, scoped := middlewares.OrgIDFromContext(c)
+
+	query := configuration.DB.Model(&models.Patient{}).Scopes(
+		PatientSearchScope(c.Query("keyword")),
+		DoctorFilterScope(c.Query("doctor_id"), c.Query("specialization")),
+		LastVisitBetweenScope(c.Query("last_visit_from"), c.Query("last_visit_to")),
+		ContagionTagsScope(c.QueryArray("contagion")),
+		OrgPatientScope(orgID, scoped),
+	)
+
+	if rawHasAppointments := c.Query("has_appointments"); rawHasAppointments != "" {
+		query = query.Scopes(HasAppointmentsScope(rawHasAppointments == "true"))
+	}
+	if rawHasUnpaid := c.Query("has_unpaid_invoices"); rawHasUnpaid != "" {
+		query = query.Scopes(HasUnpaidInvoicesScope(rawHasUnpaid == "true"))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count patients"})
+		return
+	}
+
+	var patients []models.Patient
+	if err := query.Offset((page - 1) * limit).Limit(limit).Find(&patients).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch patients"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total": total,
+		"page":  page,
+		"data":  patients,
+	})
+}
+
+// TimelineEntry is one event in a patient's history timeline.
+type TimelineEntry struct {
+	Type string      `json:"type"` // "appointment", "invoice" or "prescription"
+	Date time.Time   `json:"date"`
+	Data interface{} `json:"data"`
+}
+
+// orgDoctorIDsSubquery returns a sub-query selecting the doctor_id column of every
+// doctor in orgID, for use in a "doctor_id IN (?)" scope.
+func orgDoctorIDsSubquery(orgID int) *gorm.DB {
+	return configuration.DB.Model(&models.Doctor{}).Where("hospital_id = ?", orgID).Select("doctor_id")
+}
+
+// GetPatientTimeline returns a patient's appointments, invoices and prescriptions
+// merged into a single history sorted by date. When the caller is scoped to an
+// organization, only that organization's doctors' records are included.
+func GetPatientTimeline(c *gin.Context) {
+	patientID := c.Param("id")
+	orgID, scoped := middlewares.OrgIDFromContext(c)
+
+	appointmentQuery := configuration.DB.Where("patient_id = ?", patientID)
+	invoiceQuery := configuration.DB.Where("patient_id = ?", patientID)
+	prescriptionQuery := configuration.DB.Where("patient_id = ?", patientID)
+	if scoped {
+		appointmentQuery = appointmentQuery.Where("doctor_id IN (?)", orgDoctorIDsSubquery(orgID))
+		invoiceQuery = invoiceQuery.Where("doctor_id IN (?)", orgDoctorIDsSubquery(orgID))
+		prescriptionQuery = prescriptionQuery.Where("doctor_id IN (?)", orgDoctorIDsSubquery(orgID))
+	}
+
+	var appointments []models.Appointment
+	if err := appointmentQuery.Find(&appointments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch appointments"})
+		return
+	}
+
+	var invoices []models.Invoice
+	if err := invoiceQuery.Find(&invoices).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invoices"})
+		return
+	}
+
+	var prescriptions []models.Prescription
+	if err := prescriptionQuery.Preload("Items").Find(&prescriptions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prescriptions"})
+		return
+	}
+
+	if scoped && len(appointments) == 0 && len(invoices) == 0 && len(prescriptions) == 0 {
+		// Either the patient doesn't exist or has no record within the caller's
+		// organization; don't distinguish the two to a scoped caller.
+		c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+		return
+	}
+
+	timeline := make([]TimelineEntry, 0, len(appointments)+len(invoices)+len(prescriptions))
+	for _, appointment := range appointments {
+		timeline = append(timeline, TimelineEntry{Type: "appointment", Date: appointment.AppointmentDate, Data: appointment})
+	}
+	for _, invoice := range invoices {
+		timeline = append(timeline, TimelineEntry{Type: "invoice", Date: invoice.PaymentDueDate, Data: invoice})
+	}
+	for _, prescription := range prescriptions {
+		timeline = append(timeline, TimelineEntry{Type: "prescription", Date: prescription.IssuedAt, Data: prescription})
+	}
+
+	sort.Slice(timeline, func(i, j int) bool { return timeline[i].Date.Before(timeline[j].Date) })
+
+	c.JSON(http.StatusOK, gin.H{
+		"patient_id": patientID,
+		"timeline":   timeline,
+	})
+}
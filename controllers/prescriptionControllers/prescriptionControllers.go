@@ -0,0 +1,276 @@
+package prescriptionControllers
+
+import (
+	"doctorAppointment/configuration"
+	"doctorAppointment/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PrescriptionItemInput is one drug line in a PrescriptionInput.
+type PrescriptionItemInput struct {
+	Drug      string `json:"drug" binding:"required"`
+	Dose      string `json:"dose"`
+	Frequency string `json:"frequency"`
+	Duration  string `json:"duration"`
+}
+
+// CaseHistoryInput is the optional case-history payload attached to a prescription.
+type CaseHistoryInput struct {
+	ChiefComplaint string `json:"chief_complaint"`
+	Diagnosis      string `json:"diagnosis"`
+	Notes          string `json:"notes"`
+	Attachments    string `json:"attachments"`
+}
+
+// PrescriptionInput is the payload for CreatePrescription and UpdatePrescription.
+type PrescriptionInput struct {
+	Items       []PrescriptionItemInput `json:"items" binding:"required"`
+	CaseHistory *CaseHistoryInput       `json:"case_history"`
+}
+
+// requirePatientSelf reads the JWT-authenticated patient id off the request
+// context, the same way requireAssignedDoctor reads the doctor id.
+func requirePatientSelf(c *gin.Context) (int, bool) {
+	patientIDValue, exists := c.Get("patientID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Patient authentication required"})
+		return 0, false
+	}
+	patientID, ok := patientIDValue.(int)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Patient authentication required"})
+		return 0, false
+	}
+	return patientID, true
+}
+
+// requireAssignedDoctor checks that the JWT-authenticated doctor on the request
+// context is the doctor assigned to appointmentID, returning the appointment if so.
+func requireAssignedDoctor(c *gin.Context, appointmentID int) (*models.Appointment, bool) {
+	doctorIDValue, exists := c.Get("doctorID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Doctor authentication required"})
+		return nil, false
+	}
+	doctorID, ok := doctorIDValue.(int)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Doctor authentication required"})
+		return nil, false
+	}
+
+	var appointment models.Appointment
+	if err := configuration.DB.First(&appointment, appointmentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Appointment not found"})
+		return nil, false
+	}
+	if appointment.DoctorID != doctorID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the assigned doctor can modify this prescription"})
+		return nil, false
+	}
+	return &appointment, true
+}
+
+// CreatePrescription issues a prescription (and optional case history) for an
+// appointment, and marks the appointment completed.
+func CreatePrescription(c *gin.Context) {
+	appointmentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment id"})
+		return
+	}
+
+	appointment, ok := requireAssignedDoctor(c, appointmentID)
+	if !ok {
+		return
+	}
+
+	var input PrescriptionInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var existing models.Prescription
+	if err := configuration.DB.Where("appointment_id = ?", appointmentID).First(&existing).Error; err == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Prescription already exists for this appointment"})
+		return
+	}
+
+	items := make([]models.PrescriptionItem, 0, len(input.Items))
+	for _, item := range input.Items {
+		items = append(items, models.PrescriptionItem{
+			Drug:      item.Drug,
+			Dose:      item.Dose,
+			Frequency: item.Frequency,
+			Duration:  item.Duration,
+		})
+	}
+
+	prescription := models.Prescription{
+		AppointmentID: appointmentID,
+		DoctorID:      appointment.DoctorID,
+		PatientID:     appointment.PatientID,
+		Items:         items,
+		IssuedAt:      time.Now(),
+	}
+
+	err = configuration.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&prescription).Error; err != nil {
+			return err
+		}
+
+		if input.CaseHistory != nil {
+			caseHistory := models.CaseHistory{
+				AppointmentID:  appointmentID,
+				ChiefComplaint: input.CaseHistory.ChiefComplaint,
+				Diagnosis:      input.CaseHistory.Diagnosis,
+				Notes:          input.CaseHistory.Notes,
+				Attachments:    input.CaseHistory.Attachments,
+			}
+			if err := tx.Create(&caseHistory).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.Appointment{}).Where("appointment_id = ?", appointmentID).Update("booking_status", "completed").Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create prescription"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Status":  "Success",
+		"Message": "Prescription created successfully",
+		"data":    prescription,
+	})
+}
+
+// GetPrescription returns the prescription (with its items) for an appointment,
+// restricted to the appointment's own patient.
+func GetPrescription(c *gin.Context) {
+	appointmentID := c.Param("id")
+
+	patientID, ok := requirePatientSelf(c)
+	if !ok {
+		return
+	}
+
+	var prescription models.Prescription
+	if err := configuration.DB.Where("appointment_id = ?", appointmentID).Preload("Items").First(&prescription).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prescription not found"})
+		return
+	}
+	if prescription.PatientID != patientID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You may only view your own prescriptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": prescription})
+}
+
+// UpdatePrescription replaces the drug items on an existing prescription.
+func UpdatePrescription(c *gin.Context) {
+	appointmentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment id"})
+		return
+	}
+
+	if _, ok := requireAssignedDoctor(c, appointmentID); !ok {
+		return
+	}
+
+	var prescription models.Prescription
+	if err := configuration.DB.Where("appointment_id = ?", appointmentID).First(&prescription).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prescription not found"})
+		return
+	}
+
+	var input PrescriptionInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = configuration.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("prescription_id = ?", prescription.ID).Delete(&models.PrescriptionItem{}).Error; err != nil {
+			return err
+		}
+
+		items := make([]models.PrescriptionItem, 0, len(input.Items))
+		for _, item := range input.Items {
+			items = append(items, models.PrescriptionItem{
+				PrescriptionID: prescription.ID,
+				Drug:           item.Drug,
+				Dose:           item.Dose,
+				Frequency:      item.Frequency,
+				Duration:       item.Duration,
+			})
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.Create(&items).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update prescription"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"Status": "Success", "Message": "Prescription updated successfully"})
+}
+
+// DeletePrescription removes a prescription and its items.
+func DeletePrescription(c *gin.Context) {
+	appointmentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment id"})
+		return
+	}
+
+	if _, ok := requireAssignedDoctor(c, appointmentID); !ok {
+		return
+	}
+
+	var prescription models.Prescription
+	if err := configuration.DB.Where("appointment_id = ?", appointmentID).First(&prescription).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prescription not found"})
+		return
+	}
+
+	err = configuration.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("prescription_id = ?", prescription.ID).Delete(&models.PrescriptionItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&prescription).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prescription"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"Status": "Success", "Message": "Prescription deleted successfully"})
+}
+
+// GetPatientPrescriptions returns the JWT-authenticated patient's own full
+// prescription history.
+func GetPatientPrescriptions(c *gin.Context) {
+	patientID, ok := requirePatientSelf(c)
+	if !ok {
+		return
+	}
+
+	var prescriptions []models.Prescription
+	if err := configuration.DB.Where("patient_id = ?", patientID).Preload("Items").Find(&prescriptions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prescriptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": prescriptions})
+}
@@ -2,6 +2,7 @@ package userControllers
 
 import (
 	"doctorAppointment/configuration"
+	"doctorAppointment/middlewares"
 	"doctorAppointment/models"
 	"errors"
 	"fmt"
@@ -12,6 +13,15 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errAppointmentSlotTaken and errAppointmentAlreadyBookedSameDay are returned from
+// inside the BookAppointment transaction so the handler can map them back to the
+// existing user-facing error messages after the transaction rolls back.
+var (
+	errAppointmentSlotTaken            = errors.New("appointment slot already taken")
+	errAppointmentAlreadyBookedSameDay = errors.New("appointment already booked with the same doctor today")
 )
 
 // Function to GetAvailableTimeSlots
@@ -25,44 +35,230 @@ func GetAvailableTimeSlots(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
 		return
 	}
+	if !doctorInCallerOrg(c, doctorID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Doctor not in caller's organization"})
+		return
+	}
 
-	// Query database for doctor's availability on the specified date
-	var availability models.DoctorAvailability
-	if err := configuration.DB.Where("doctor_id = ? AND date = ?", doctorID, date).First(&availability).Error; err != nil {
+	panel, err := buildSchedulePanel(doctorID, date, date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bookings"})
+		return
+	}
+	if len(panel) == 0 || panel[0].Slots == nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Availability not found"})
 		return
 	}
 
-	// Split availability time into start and end time
-	startTime, endTime := splitAvailabilityTime(availability.AvilableTime)
+	// Filter out available time slots that are already booked
+	adjustedTimeSlots := make([]string, 0)
+	for _, slot := range panel[0].Slots {
+		if slot.Status == SlotStatusAvailable {
+			adjustedTimeSlots = append(adjustedTimeSlots, slot.TimeSlot)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"date":                 dateStr,
+		"available_time_slots": adjustedTimeSlots,
+	})
+}
+
+// SlotStatus describes what a single slot in a schedule panel represents.
+type SlotStatus string
+
+const (
+	SlotStatusAvailable SlotStatus = "available"
+	SlotStatusBooked    SlotStatus = "booked"
+	SlotStatusBlocked   SlotStatus = "blocked"
+	SlotStatusOff       SlotStatus = "off"
+)
+
+// DaySlot is a single time slot within a DaySchedule, tagged with its current status.
+type DaySlot struct {
+	TimeSlot string     `json:"time_slot"`
+	Status   SlotStatus `json:"status"`
+}
 
-	// Divide time between start and end time into 30-minute intervals to create time slots
-	availableTimeSlots := divideSlots(startTime, endTime, 30*time.Minute)
+// DaySchedule is one day's worth of slots. Slots is nil when the doctor has no
+// availability row and no matching template for that date, i.e. the whole day is off.
+type DaySchedule struct {
+	Date  string    `json:"date"`
+	Slots []DaySlot `json:"slots"`
+}
+
+// buildSchedulePanel generates a day-by-day slot panel for doctorID across
+// [start, end] (inclusive). It fetches DoctorAvailability and Appointment rows
+// for the whole range in a single query each, instead of one query per day.
+func buildSchedulePanel(doctorID string, start, end time.Time) ([]DaySchedule, error) {
+	var availabilities []models.DoctorAvailability
+	if err := configuration.DB.Where("doctor_id = ? AND date BETWEEN ? AND ?", doctorID, start, end).Find(&availabilities).Error; err != nil {
+		return nil, err
+	}
+	availabilityByDate := make(map[string]models.DoctorAvailability, len(availabilities))
+	for _, availability := range availabilities {
+		availabilityByDate[availability.Date.Format("2006-01-02")] = availability
+	}
 
-	// Query database for existing bookings for the doctor on the specified date
+	// Only "pending"/"paid" appointments hold a slot (matching isAppointmentAvailable
+	// and the migrations/0001 partial unique index), so a cancelled appointment
+	// doesn't keep marking its slot booked forever.
 	var bookings []models.Appointment
-	if err := configuration.DB.Where("doctor_id = ? AND appointment_date = ?", doctorID, date).Find(&bookings).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bookings"})
+	if err := configuration.DB.Where("doctor_id = ? AND appointment_date BETWEEN ? AND ? AND payment_status IN ?", doctorID, start, end, []string{"pending", "paid"}).Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+	bookedByDate := make(map[string]map[string]bool)
+	for _, booking := range bookings {
+		dateKey := booking.AppointmentDate.Format("2006-01-02")
+		if bookedByDate[dateKey] == nil {
+			bookedByDate[dateKey] = make(map[string]bool)
+		}
+		bookedByDate[dateKey][booking.AppointmentTimeSlot] = true
+	}
+
+	var blocks []models.DoctorAvailabilityBlock
+	if err := configuration.DB.Where("doctor_id = ? AND date BETWEEN ? AND ?", doctorID, start, end).Find(&blocks).Error; err != nil {
+		return nil, err
+	}
+	blockedByDate := make(map[string]map[string]bool)
+	for _, block := range blocks {
+		dateKey := block.Date.Format("2006-01-02")
+		if blockedByDate[dateKey] == nil {
+			blockedByDate[dateKey] = make(map[string]bool)
+		}
+		blockedByDate[dateKey][block.TimeSlot] = true
+	}
+
+	panel := make([]DaySchedule, 0, 7)
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		dateKey := d.Format("2006-01-02")
+		day := DaySchedule{Date: dateKey}
+
+		availability, hasAvailability := availabilityByDate[dateKey]
+		slotDuration := 30 * time.Minute
+		breakStart, breakEnd := "", ""
+
+		// Look up the originating template even for an explicit availability row (e.g.
+		// one eagerly materialized by ApplyTemplate), since that row only copies
+		// AvilableTime and doesn't carry the template's break window or slot duration.
+		templated, template := materializeFromTemplate(doctorID, d)
+		if !hasAvailability {
+			if templated == nil {
+				// No availability row and no matching template: the doctor is off for the whole day.
+				panel = append(panel, day)
+				continue
+			}
+			availability = *templated
+		}
+		if template != nil {
+			if template.SlotDurationMins > 0 {
+				slotDuration = time.Duration(template.SlotDurationMins) * time.Minute
+			}
+			breakStart, breakEnd = template.BreakStart, template.BreakEnd
+		}
+
+		startTime, endTime := splitAvailabilityTime(availability.AvilableTime)
+		booked := bookedByDate[dateKey]
+		blocked := blockedByDate[dateKey]
+		for _, slot := range divideSlots(startTime, endTime, slotDuration) {
+			if breakStart != "" && breakEnd != "" && slotWithinBreak(slot, breakStart, breakEnd) {
+				continue // break window: not part of the panel at all
+			}
+			status := SlotStatusAvailable
+			if booked[slot] {
+				status = SlotStatusBooked
+			} else if blocked[slot] {
+				status = SlotStatusBlocked
+			}
+			day.Slots = append(day.Slots, DaySlot{TimeSlot: slot, Status: status})
+		}
+		panel = append(panel, day)
+	}
+	return panel, nil
+}
+
+// GetWeeklySchedule returns a 7-day panel of slots for a single doctor, starting
+// at week_start, with each slot tagged available/booked/blocked. A day the doctor
+// is off is returned with no slots at all; no slot is tagged off.
+func GetWeeklySchedule(c *gin.Context) {
+	doctorID := c.Param("doctor_id")
+	weekStartStr := c.Query("week_start")
+
+	weekStart, err := time.Parse("2006-01-02", weekStartStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid week_start format"})
+		return
+	}
+	if !doctorInCallerOrg(c, doctorID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Doctor not in caller's organization"})
 		return
 	}
+	weekEnd := weekStart.AddDate(0, 0, 6)
 
-	//Map to store booked time slots
-	bookedTimeSlots := make(map[string]bool)
-	for _, booking := range bookings {
-		bookedTimeSlots[booking.AppointmentTimeSlot] = true
+	panel, err := buildSchedulePanel(doctorID, weekStart, weekEnd)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build weekly schedule"})
+		return
 	}
 
-	// Filter out available time slots that are already booked
-	adjustedTimeSlots := make([]string, 0)
-	for _, slot := range availableTimeSlots {
-		if !bookedTimeSlots[slot] {
-			adjustedTimeSlots = append(adjustedTimeSlots, slot)
+	c.JSON(http.StatusOK, gin.H{
+		"doctor_id":  doctorID,
+		"week_start": weekStartStr,
+		"panel":      panel,
+	})
+}
+
+// DoctorSchedule pairs a doctor with their weekly schedule panel, used when a
+// partition of doctors (e.g. a hospital or specialization) is requested together.
+type DoctorSchedule struct {
+	Doctor models.Doctor `json:"doctor"`
+	Panel  []DaySchedule `json:"panel"`
+}
+
+// GetHospitalWeeklySchedule returns the weekly schedule panel for every approved
+// doctor at a hospital (optionally narrowed by specialization) as one matrix,
+// so the frontend can render a calendar view instead of polling doctor-by-doctor.
+func GetHospitalWeeklySchedule(c *gin.Context) {
+	hospitalID := c.Param("id")
+	weekStartStr := c.Query("week_start")
+	specialization := c.Query("specialization")
+
+	if orgID, scoped := middlewares.OrgIDFromContext(c); scoped && fmt.Sprint(orgID) != hospitalID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Hospital not in caller's organization"})
+		return
+	}
+
+	weekStart, err := time.Parse("2006-01-02", weekStartStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid week_start format"})
+		return
+	}
+	weekEnd := weekStart.AddDate(0, 0, 6)
+
+	query := configuration.DB.Where("hospital_id = ? AND approved = ?", hospitalID, "true")
+	if specialization != "" {
+		query = query.Where("specialization = ?", specialization)
+	}
+	var doctors []models.Doctor
+	if err := query.Find(&doctors).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch doctors"})
+		return
+	}
+
+	matrix := make([]DoctorSchedule, 0, len(doctors))
+	for _, doctor := range doctors {
+		panel, err := buildSchedulePanel(fmt.Sprint(doctor.DoctorID), weekStart, weekEnd)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build weekly schedule"})
+			return
 		}
+		matrix = append(matrix, DoctorSchedule{Doctor: doctor, Panel: panel})
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"date":                 dateStr,
-		"available_time_slots": adjustedTimeSlots,
+		"hospital_id": hospitalID,
+		"week_start":  weekStartStr,
+		"data":        matrix,
 	})
 }
 
@@ -110,7 +306,11 @@ func GetDoctorsBySpeciality(c *gin.Context) {
 	doctorSpeciality := c.Param("specialization")
 
 	// Query the database to find doctors with the specified speciality who are approved
-	if err := configuration.DB.Where("specialization = ? AND approved = ?", doctorSpeciality, "true").Find(&doctors).Error; err != nil {
+	query := configuration.DB.Where("specialization = ? AND approved = ?", doctorSpeciality, "true")
+	if orgID, scoped := middlewares.OrgIDFromContext(c); scoped {
+		query = query.Scopes(middlewares.OrgScope("hospital_id", orgID))
+	}
+	if err := query.Find(&doctors).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "No doctors found with the specified speciality"})
 			return
@@ -164,6 +364,31 @@ func BookAppointment(c *gin.Context) {
 		return
 	}
 
+	if !doctorInCallerOrg(c, fmt.Sprint(booking.DoctorID)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Doctor not in caller's organization"})
+		return
+	}
+
+	// A retried POST with the same Idempotency-Key returns the appointment from the
+	// original request instead of creating a second one.
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if idempotencyKey != "" {
+		var existingKey models.IdempotencyKey
+		if err := configuration.DB.Where("key = ?", idempotencyKey).First(&existingKey).Error; err == nil {
+			var existingBooking models.Appointment
+			var existingInvoice models.Invoice
+			configuration.DB.First(&existingBooking, existingKey.AppointmentID)
+			configuration.DB.Where("appointment_id = ?", existingKey.AppointmentID).First(&existingInvoice)
+			c.JSON(http.StatusOK, gin.H{
+				"Status":  "Success",
+				"Message": "Appointment booked successfully",
+				"Data":    existingBooking,
+				"Invoice": existingInvoice,
+			})
+			return
+		}
+	}
+
 	// Check if the appointment time slot is within the available time slots of the doctor
 	doctorAvailability := getDoctorAvailability(booking.DoctorID, booking.AppointmentDate)
 	if doctorAvailability == nil {
@@ -180,12 +405,6 @@ func BookAppointment(c *gin.Context) {
 		return
 	}
 
-	// Check for existing appointments with the same date and time slot
-	if !isAppointmentAvailable(booking.DoctorID, booking.AppointmentDate, booking.AppointmentTimeSlot) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Appointment has been already booked for the same date and time slot with the doctor"})
-		return
-	}
-
 	// Check if the patient exists
 	var patient models.Patient
 	if err := configuration.DB.Where("patient_id = ?", booking.PatientID).First(&patient).Error; err != nil {
@@ -193,60 +412,361 @@ func BookAppointment(c *gin.Context) {
 		return
 	}
 
-	// Check for duplicate appointments with the same doctor on the same day
-	if !isDuplicateAppointment(booking.PatientID, booking.DoctorID, booking.AppointmentDate) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Your Appointment has been already booked with the same doctor in the same day"})
+	// Everything from here on runs inside one transaction: the doctor's
+	// DoctorAvailability row for this date is locked FOR UPDATE so a concurrent
+	// request for the same slot blocks instead of racing past the checks below.
+	var invoice models.Invoice
+	err := configuration.DB.Transaction(func(tx *gorm.DB) error {
+		var lockedAvailability models.DoctorAvailability
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("doctor_id = ? AND date = ?", booking.DoctorID, booking.AppointmentDate).
+			First(&lockedAvailability).Error; err != nil {
+			return err
+		}
+
+		if !isAppointmentAvailable(tx, booking.DoctorID, booking.AppointmentDate, booking.AppointmentTimeSlot) {
+			return errAppointmentSlotTaken
+		}
+		if !isDuplicateAppointment(tx, booking.PatientID, booking.DoctorID, booking.AppointmentDate) {
+			return errAppointmentAlreadyBookedSameDay
+		}
+
+		// Create the appointment
+		booking.BookingStatus = "pending"
+		booking.PaymentStatus = "pending"
+		if err := tx.Create(&booking).Error; err != nil {
+			if isUniqueViolation(err) {
+				// A concurrent request slipped in between the isAppointmentAvailable
+				// check above and this insert; the unique index on
+				// (doctor_id, appointment_date, appointment_time_slot) caught it.
+				return errAppointmentSlotTaken
+			}
+			return err
+		}
+
+		// Fetch doctor's consultancy charge
+		var doctor models.Doctor
+		if err := tx.Where("doctor_id = ?", booking.DoctorID).First(&doctor).Error; err != nil {
+			return err
+		}
+
+		// Create the invoice
+		invoice = models.Invoice{
+			DoctorID:       uint(booking.DoctorID),
+			PatientID:      uint(booking.PatientID),
+			AppointmentID:  uint(booking.AppointmentID),
+			TotalAmount:    float64(doctor.ConsultancyCharge) + 50,
+			PaymentMethod:  "Pending", // Payment method set to pending initially
+			PaymentStatus:  "Pending",
+			PaymentDueDate: time.Now().AddDate(0, 0, 1), // Payment due date set to 1 day from now
+		}
+		if err := tx.Create(&invoice).Error; err != nil {
+			return err
+		}
+
+		if idempotencyKey != "" {
+			if err := tx.Create(&models.IdempotencyKey{Key: idempotencyKey, AppointmentID: uint(booking.AppointmentID)}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, errAppointmentSlotTaken):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Appointment has been already booked for the same date and time slot with the doctor"})
+		case errors.Is(err, errAppointmentAlreadyBookedSameDay):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Your Appointment has been already booked with the same doctor in the same day"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to book appointment"})
+		}
 		return
 	}
-	// Create the appointment
-	booking.BookingStatus = "pending"
-	booking.PaymentStatus = "pending"
-	if err := configuration.DB.Create(&booking).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to book appointment"})
+
+	c.JSON(http.StatusOK, gin.H{
+		"Status":  "Success",
+		"Message": "Appointment booked successfully",
+		"Data":    booking,
+		"Invoice": invoice,
+	})
+}
+
+// CancelAppointment cancels a booked appointment and frees its slot, taking the
+// same doctor-availability row lock as BookAppointment so a cancellation can never
+// race a concurrent booking attempt for that slot.
+func CancelAppointment(c *gin.Context) {
+	appointmentID := c.Param("id")
+
+	var existing models.Appointment
+	if err := configuration.DB.First(&existing, appointmentID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Appointment not found"})
+		return
+	}
+	if !doctorInCallerOrg(c, fmt.Sprint(existing.DoctorID)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Doctor not in caller's organization"})
+		return
+	}
+
+	err := configuration.DB.Transaction(func(tx *gorm.DB) error {
+		var appointment models.Appointment
+		if err := tx.First(&appointment, appointmentID).Error; err != nil {
+			return err
+		}
+
+		var lockedAvailability models.DoctorAvailability
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("doctor_id = ? AND date = ?", appointment.DoctorID, appointment.AppointmentDate).
+			First(&lockedAvailability).Error; err != nil {
+			return err
+		}
+
+		// payment_status must leave the ('pending', 'paid') set that isAppointmentAvailable
+		// and the migrations/0001 partial unique index key off, or the slot stays
+		// permanently taken after cancellation.
+		appointment.BookingStatus = "cancelled"
+		appointment.PaymentStatus = "cancelled"
+		if err := tx.Save(&appointment).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Invoice{}).
+			Where("appointment_id = ?", appointment.AppointmentID).
+			Update("payment_status", "Cancelled").Error
+	})
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Appointment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel appointment"})
 		return
 	}
 
-	// Fetch doctor's consultancy charge
+	c.JSON(http.StatusOK, gin.H{
+		"Status":  "Success",
+		"Message": "Appointment cancelled successfully",
+	})
+}
+
+// isUniqueViolation reports whether err looks like a unique/duplicate-key
+// constraint violation, independent of which SQL driver is configured.
+func isUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "unique") || strings.Contains(msg, "duplicate")
+}
+
+// doctorInCallerOrg reports whether doctorID belongs to the caller's organization.
+// A super_admin caller (no org resolved) bypasses the check entirely.
+func doctorInCallerOrg(c *gin.Context, doctorID string) bool {
+	orgID, scoped := middlewares.OrgIDFromContext(c)
+	if !scoped {
+		return true
+	}
 	var doctor models.Doctor
-	if err := configuration.DB.Where("doctor_id = ?", booking.DoctorID).First(&doctor).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch doctor's consultancy charge"})
+	return configuration.DB.Where("doctor_id = ? AND hospital_id = ?", doctorID, orgID).First(&doctor).Error == nil
+}
+
+// getDoctorAvailability retrieves the availability of a doctor on a specific date,
+// falling back to a recurring DoctorAvailabilityTemplate when no explicit row exists.
+func getDoctorAvailability(doctorID int, date time.Time) *models.DoctorAvailability {
+	var availability models.DoctorAvailability
+	if err := configuration.DB.Where("doctor_id = ? AND date = ?", doctorID, date).First(&availability).Error; err == nil {
+		return &availability
+	}
+	templated, _ := materializeFromTemplate(fmt.Sprint(doctorID), date)
+	return templated
+}
+
+// materializeFromTemplate builds an in-memory DoctorAvailability for doctorID on
+// date from its recurring template, honoring a per-day override (holiday closure
+// or half-day) when one exists, and returns the template alongside it so callers
+// can also apply its break window and slot duration. Returns (nil, nil) if the
+// doctor is off that day.
+func materializeFromTemplate(doctorID string, date time.Time) (*models.DoctorAvailability, *models.DoctorAvailabilityTemplate) {
+	var override models.DoctorAvailabilityOverride
+	hasOverride := configuration.DB.Where("doctor_id = ? AND date = ?", doctorID, date).First(&override).Error == nil
+	if hasOverride && override.Closed {
+		return nil, nil
+	}
+
+	var template models.DoctorAvailabilityTemplate
+	if err := configuration.DB.Where("doctor_id = ? AND valid_from <= ? AND valid_until >= ?", doctorID, date, date).First(&template).Error; err != nil {
+		return nil, nil
+	}
+	if !strings.Contains(template.Weekdays, date.Format("Mon")) {
+		return nil, nil
+	}
+
+	availableTime := template.AvailableTime
+	if hasOverride && override.AvailableTime != "" {
+		availableTime = override.AvailableTime
+	}
+
+	return &models.DoctorAvailability{
+		DoctorID:     template.DoctorID,
+		Date:         date,
+		AvilableTime: availableTime,
+	}, &template
+}
+
+// slotWithinBreak reports whether slot (formatted "15:04-15:04") starts inside the
+// half-open break window [breakStart, breakEnd).
+func slotWithinBreak(slot, breakStart, breakEnd string) bool {
+	parts := strings.Split(slot, "-")
+	if len(parts) != 2 {
+		return false
+	}
+	slotStart, err := time.Parse("15:04", parts[0])
+	if err != nil {
+		return false
+	}
+	start, err := time.Parse("15:04", breakStart)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse("15:04", breakEnd)
+	if err != nil {
+		return false
+	}
+	return !slotStart.Before(start) && slotStart.Before(end)
+}
+
+// CreateAvailabilityTemplateInput is the payload for CreateAvailabilityTemplate.
+type CreateAvailabilityTemplateInput struct {
+	DoctorID         int    `json:"doctor_id" binding:"required"`
+	Weekdays         string `json:"weekdays" binding:"required"`
+	AvailableTime    string `json:"available_time" binding:"required"`
+	BreakStart       string `json:"break_start"`
+	BreakEnd         string `json:"break_end"`
+	SlotDurationMins int    `json:"slot_duration_minutes"`
+	ValidFrom        string `json:"valid_from" binding:"required"`
+	ValidUntil       string `json:"valid_until" binding:"required"`
+}
+
+// CreateAvailabilityTemplate stores a recurring weekly availability pattern for a
+// doctor, to be expanded into concrete DoctorAvailability rows on read or via ApplyTemplate.
+func CreateAvailabilityTemplate(c *gin.Context) {
+	var input CreateAvailabilityTemplateInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Calculate total amount for the invoice
-	totalAmount := doctor.ConsultancyCharge
+	validFrom, err := time.Parse("2006-01-02", input.ValidFrom)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid valid_from format"})
+		return
+	}
+	validUntil, err := time.Parse("2006-01-02", input.ValidUntil)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid valid_until format"})
+		return
+	}
 
-	// Create the invoice
-	invoice := models.Invoice{
-		DoctorID:       uint(booking.DoctorID),
-		PatientID:      uint(booking.PatientID),
-		AppointmentID:  uint(booking.AppointmentID),
-		TotalAmount:    float64(totalAmount) + 50,
-		PaymentMethod:  "Pending", // Payment method set to pending initially
-		PaymentStatus:  "Pending",
-		PaymentDueDate: time.Now().AddDate(0, 0, 1), // Payment due date set to 1 day from now
+	slotDuration := input.SlotDurationMins
+	if slotDuration == 0 {
+		slotDuration = 30
 	}
 
-	if err := configuration.DB.Create(&invoice).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create invoice"})
+	template := models.DoctorAvailabilityTemplate{
+		DoctorID:         input.DoctorID,
+		Weekdays:         input.Weekdays,
+		AvailableTime:    input.AvailableTime,
+		BreakStart:       input.BreakStart,
+		BreakEnd:         input.BreakEnd,
+		SlotDurationMins: slotDuration,
+		ValidFrom:        validFrom,
+		ValidUntil:       validUntil,
+	}
+	if err := configuration.DB.Create(&template).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create availability template"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"Status":  "Success",
-		"Message": "Appointment booked successfully",
-		"Data":    booking,
-		"Invoice": invoice,
+		"Message": "Availability template created successfully",
+		"data":    template,
 	})
 }
 
-// getDoctorAvailability retrieves the availability of a doctor on a specific date
-func getDoctorAvailability(doctorID int, date time.Time) *models.DoctorAvailability {
-	var availability models.DoctorAvailability
-	if err := configuration.DB.Where("doctor_id = ? AND date = ?", doctorID, date).First(&availability).Error; err != nil {
-		return nil
+// ApplyTemplateInput is the payload for ApplyTemplate.
+type ApplyTemplateInput struct {
+	TemplateID uint   `json:"template_id" binding:"required"`
+	From       string `json:"from" binding:"required"`
+	To         string `json:"to" binding:"required"`
+}
+
+// ApplyTemplate eagerly materializes a template's recurring slots into concrete
+// DoctorAvailability rows for [from, to]. Dates that already have an explicit row
+// are left untouched, so a prior override or manual edit is never overwritten.
+func ApplyTemplate(c *gin.Context) {
+	var input ApplyTemplateInput
+	if err := c.BindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var template models.DoctorAvailabilityTemplate
+	if err := configuration.DB.First(&template, input.TemplateID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	from, err := time.Parse("2006-01-02", input.From)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date format"})
+		return
 	}
-	return &availability
+	to, err := time.Parse("2006-01-02", input.To)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date format"})
+		return
+	}
+
+	created := 0
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		if d.Before(template.ValidFrom) || d.After(template.ValidUntil) {
+			continue
+		}
+		if !strings.Contains(template.Weekdays, d.Format("Mon")) {
+			continue
+		}
+
+		var existing models.DoctorAvailability
+		if err := configuration.DB.Where("doctor_id = ? AND date = ?", template.DoctorID, d).First(&existing).Error; err == nil {
+			continue
+		}
+
+		availableTime := template.AvailableTime
+		var override models.DoctorAvailabilityOverride
+		if err := configuration.DB.Where("doctor_id = ? AND date = ?", template.DoctorID, d).First(&override).Error; err == nil {
+			if override.Closed {
+				continue
+			}
+			if override.AvailableTime != "" {
+				availableTime = override.AvailableTime
+			}
+		}
+
+		row := models.DoctorAvailability{DoctorID: template.DoctorID, Date: d, AvilableTime: availableTime}
+		if err := configuration.DB.Create(&row).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to materialize template"})
+			return
+		}
+		created++
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"Status":  "Success",
+		"Message": "Template applied",
+		"created": created,
+	})
 }
 
 // isTimeWithinAvailableSlot checks if the appointment time slot falls within the available time slots
@@ -259,10 +779,14 @@ func isTimeWithinAvailableSlot(appointmentTimeSlot string, availableSlots []stri
 	return false
 }
 
-// isAppointmentAvailable checks if there is already an appointment booked with the same doctor, date, and time slot
-func isAppointmentAvailable(doctorID int, date time.Time, appointmentTimeSlot string) bool {
+// isAppointmentAvailable checks if there is already an appointment booked with the
+// same doctor, date, and time slot. "pending" holds the slot just like "paid" does,
+// matching the payment_status IN ('pending', 'paid') unique index on appointments,
+// so a second request for a still-pending slot is rejected here instead of failing
+// with a raw constraint violation.
+func isAppointmentAvailable(db *gorm.DB, doctorID int, date time.Time, appointmentTimeSlot string) bool {
 	var existingAppointment models.Appointment
-	err := configuration.DB.Where("doctor_id = ? AND appointment_date = ? AND appointment_time_slot = ? AND payment_status = ?", doctorID, date, appointmentTimeSlot, "paid").First(&existingAppointment).Error
+	err := db.Where("doctor_id = ? AND appointment_date = ? AND appointment_time_slot = ? AND payment_status IN ?", doctorID, date, appointmentTimeSlot, []string{"pending", "paid"}).First(&existingAppointment).Error
 	if err == nil {
 		return false // Appointment already exists for the same date and time slot
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -297,9 +821,9 @@ func divideAvailableSlots(availability string, interval time.Duration) []string
 }
 
 // isDuplicateAppointment checks if there are any duplicate appointments with the same doctor on the same day
-func isDuplicateAppointment(patientID int, doctorID int, date time.Time) bool {
+func isDuplicateAppointment(db *gorm.DB, patientID int, doctorID int, date time.Time) bool {
 	var existingAppointments []models.Appointment
-	err := configuration.DB.Where("patient_id = ? AND doctor_id = ? AND appointment_date = ? AND payment_status =?", patientID, doctorID, date, "paid").Find(&existingAppointments).Error
+	err := db.Where("patient_id = ? AND doctor_id = ? AND appointment_date = ? AND payment_status =?", patientID, doctorID, date, "paid").Find(&existingAppointments).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return true // No existing appointments found for the same doctor and date
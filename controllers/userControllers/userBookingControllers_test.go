@@ -0,0 +1,95 @@
+package userControllers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSplitAvailabilityTime(t *testing.T) {
+	start, end := splitAvailabilityTime("09:00 - 17:00")
+	if start != "09:00" || end != "17:00" {
+		t.Fatalf("got start=%q end=%q, want 09:00/17:00", start, end)
+	}
+
+	start, end = splitAvailabilityTime("not-a-range-at-all-here")
+	if start != "" || end != "" {
+		t.Fatalf("got start=%q end=%q, want empty strings for malformed input", start, end)
+	}
+}
+
+func TestDivideSlots(t *testing.T) {
+	slots := divideSlots("09:00", "10:00", 30*time.Minute)
+	want := []string{"09:00-09:30", "09:30-10:00"}
+	if len(slots) != len(want) {
+		t.Fatalf("got %v, want %v", slots, want)
+	}
+	for i := range want {
+		if slots[i] != want[i] {
+			t.Fatalf("got %v, want %v", slots, want)
+		}
+	}
+}
+
+func TestDivideSlotsCustomDuration(t *testing.T) {
+	slots := divideSlots("09:00", "09:45", 15*time.Minute)
+	want := []string{"09:00-09:15", "09:15-09:30", "09:30-09:45"}
+	if len(slots) != len(want) {
+		t.Fatalf("got %v, want %v", slots, want)
+	}
+	for i := range want {
+		if slots[i] != want[i] {
+			t.Fatalf("got %v, want %v", slots, want)
+		}
+	}
+}
+
+func TestSlotWithinBreak(t *testing.T) {
+	cases := []struct {
+		slot       string
+		breakStart string
+		breakEnd   string
+		want       bool
+	}{
+		{"12:00-12:30", "12:00", "13:00", true},
+		{"12:30-13:00", "12:00", "13:00", true},
+		{"13:00-13:30", "12:00", "13:00", false},
+		{"11:30-12:00", "12:00", "13:00", false},
+		{"malformed", "12:00", "13:00", false},
+	}
+	for _, tc := range cases {
+		if got := slotWithinBreak(tc.slot, tc.breakStart, tc.breakEnd); got != tc.want {
+			t.Errorf("slotWithinBreak(%q, %q, %q) = %v, want %v", tc.slot, tc.breakStart, tc.breakEnd, got, tc.want)
+		}
+	}
+}
+
+func TestIsTimeWithinAvailableSlot(t *testing.T) {
+	available := []string{"09:00-09:30", "09:30-10:00"}
+	if !isTimeWithinAvailableSlot("09:00-09:30", available) {
+		t.Fatal("expected 09:00-09:30 to be within available slots")
+	}
+	if isTimeWithinAvailableSlot("10:00-10:30", available) {
+		t.Fatal("expected 10:00-10:30 to not be within available slots")
+	}
+}
+
+func TestIsUniqueViolation(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errString("duplicate key value violates unique constraint"), true},
+		{errString("UNIQUE constraint failed: appointments.doctor_id"), true},
+		{errString("connection refused"), false},
+	}
+	for _, tc := range cases {
+		if got := isUniqueViolation(tc.err); got != tc.want {
+			t.Errorf("isUniqueViolation(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+type errString string
+
+func (e errString) Error() string { return string(e) }
@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ResolveOrganization reads the caller's organization (hospital) id from the JWT
+// claims already placed on the Gin context by the auth middleware, and stores it
+// as "orgID" for downstream handlers to scope their queries with OrgScope. A
+// caller with the super_admin role is exempt and sees every organization.
+func ResolveOrganization() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if role, _ := c.Get("role"); role == "super_admin" {
+			c.Next()
+			return
+		}
+
+		hospitalIDValue, exists := c.Get("hospitalID")
+		if !exists {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing organization claim"})
+			c.Abort()
+			return
+		}
+		hospitalID, ok := hospitalIDValue.(int)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid organization claim"})
+			c.Abort()
+			return
+		}
+
+		c.Set("orgID", hospitalID)
+		c.Next()
+	}
+}
+
+// OrgIDFromContext reads the org id resolved by ResolveOrganization. The second
+// return value is false for a super_admin caller, who isn't scoped to any one org.
+func OrgIDFromContext(c *gin.Context) (int, bool) {
+	orgIDValue, exists := c.Get("orgID")
+	if !exists {
+		return 0, false
+	}
+	orgID, ok := orgIDValue.(int)
+	return orgID, ok
+}
+
+// OrgScope narrows a query to rows belonging to orgID via the given column, e.g.
+// "hospital_id" on doctors. Tables that don't carry hospital_id directly (appointments,
+// invoices, doctor availability) are scoped instead by joining through their doctor,
+// typically with a "doctor_id IN (SELECT doctor_id FROM doctors WHERE hospital_id = ?)"
+// sub-query rather than this scope.
+func OrgScope(column string, orgID int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where(column+" = ?", orgID)
+	}
+}
@@ -0,0 +1,71 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTestContext() (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	return c, recorder
+}
+
+func TestResolveOrganizationSetsOrgIDFromHospitalClaim(t *testing.T) {
+	c, recorder := newTestContext()
+	c.Set("role", "doctor")
+	c.Set("hospitalID", 7)
+
+	ResolveOrganization()(c)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected no abort, got status %d", recorder.Code)
+	}
+	orgID, scoped := OrgIDFromContext(c)
+	if !scoped || orgID != 7 {
+		t.Fatalf("got orgID=%d scoped=%v, want 7/true", orgID, scoped)
+	}
+}
+
+func TestResolveOrganizationBypassesSuperAdmin(t *testing.T) {
+	c, recorder := newTestContext()
+	c.Set("role", "super_admin")
+
+	ResolveOrganization()(c)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected no abort, got status %d", recorder.Code)
+	}
+	orgID, scoped := OrgIDFromContext(c)
+	if scoped || orgID != 0 {
+		t.Fatalf("got orgID=%d scoped=%v, want 0/false for super_admin", orgID, scoped)
+	}
+}
+
+func TestResolveOrganizationRejectsMissingClaim(t *testing.T) {
+	c, recorder := newTestContext()
+	c.Set("role", "doctor")
+
+	ResolveOrganization()(c)
+
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("got status %d, want 401 for missing organization claim", recorder.Code)
+	}
+	if !c.IsAborted() {
+		t.Fatal("expected request to be aborted when hospitalID claim is missing")
+	}
+}
+
+func TestOrgIDFromContextWhenUnset(t *testing.T) {
+	c, _ := newTestContext()
+
+	orgID, scoped := OrgIDFromContext(c)
+	if scoped || orgID != 0 {
+		t.Fatalf("got orgID=%d scoped=%v, want 0/false when orgID was never set", orgID, scoped)
+	}
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DoctorAvailabilityBlock marks a specific slot within an otherwise available day
+// as blocked (e.g. an admin hold or a meeting), distinct from a whole-day
+// DoctorAvailabilityOverride closure.
+type DoctorAvailabilityBlock struct {
+	gorm.Model
+	DoctorID int       `json:"doctor_id" gorm:"not null;index"`
+	Date     time.Time `json:"date" gorm:"not null;index"`
+	TimeSlot string    `json:"time_slot" gorm:"not null"`
+}
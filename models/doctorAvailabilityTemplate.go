@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DoctorAvailabilityTemplate describes a recurring weekly availability pattern for a
+// doctor (e.g. "Mon,Wed,Fri 09:00-13:00") that is lazily materialized into concrete
+// DoctorAvailability rows on read, instead of requiring the admin to insert one row
+// per calendar day.
+type DoctorAvailabilityTemplate struct {
+	gorm.Model
+	DoctorID         int       `json:"doctor_id" gorm:"not null;index"`
+	Weekdays         string    `json:"weekdays"`        // comma-separated Go weekday abbreviations, e.g. "Mon,Wed,Fri"
+	AvailableTime    string    `json:"available_time"`  // "09:00-13:00"
+	BreakStart       string    `json:"break_start"`
+	BreakEnd         string    `json:"break_end"`
+	SlotDurationMins int       `json:"slot_duration_minutes" gorm:"default:30"`
+	ValidFrom        time.Time `json:"valid_from"`
+	ValidUntil       time.Time `json:"valid_until"`
+}
+
+// DoctorAvailabilityOverride records a per-day exception to a template, such as a
+// holiday closure or a half-day, keyed by (doctor_id, date). An override with
+// Closed set hides the day entirely; a non-empty AvailableTime replaces the
+// template's hours for that one day.
+type DoctorAvailabilityOverride struct {
+	gorm.Model
+	DoctorID      int       `json:"doctor_id" gorm:"not null;index"`
+	Date          time.Time `json:"date" gorm:"not null;index"`
+	Closed        bool      `json:"closed"`
+	AvailableTime string    `json:"available_time"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExportJob tracks an appointments/invoices export saved to disk behind a download
+// token, so it can be fetched later instead of streaming the file on the original
+// request. The file is generated during that original request; the token can be
+// used for any number of downloads.
+type ExportJob struct {
+	gorm.Model
+	Token     string    `json:"token" gorm:"uniqueIndex;not null"`
+	Kind      string    `json:"kind"`   // "appointments" or "invoices"
+	Status    string    `json:"status"` // "ready" once saved; GetExportDownload answers 202 for any other value
+	FilePath  string    `json:"file_path"`
+	ExpiresAt time.Time `json:"expires_at"`
+	OrgID     int       `json:"org_id"`    // the requester's organization, when scoped
+	OrgScoped bool      `json:"org_scoped"` // false for a super_admin's cross-org export
+}
@@ -0,0 +1,12 @@
+package models
+
+import "gorm.io/gorm"
+
+// IdempotencyKey records the Idempotency-Key header of a BookAppointment request
+// against the appointment it created, so a retried POST with the same key returns
+// the original booking instead of creating a duplicate one.
+type IdempotencyKey struct {
+	gorm.Model
+	Key           string `json:"key" gorm:"uniqueIndex;not null"`
+	AppointmentID uint   `json:"appointment_id" gorm:"not null"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Prescription is the drug list a doctor issues for one appointment. Each
+// appointment can have at most one prescription.
+type Prescription struct {
+	gorm.Model
+	AppointmentID int                `json:"appointment_id" gorm:"not null;uniqueIndex"`
+	DoctorID      int                `json:"doctor_id" gorm:"not null"`
+	PatientID     int                `json:"patient_id" gorm:"not null"`
+	Items         []PrescriptionItem `json:"items"`
+	IssuedAt      time.Time          `json:"issued_at"`
+}
+
+// PrescriptionItem is a single drug line within a Prescription.
+type PrescriptionItem struct {
+	gorm.Model
+	PrescriptionID uint   `json:"prescription_id" gorm:"not null;index"`
+	Drug           string `json:"drug" gorm:"not null"`
+	Dose           string `json:"dose"`
+	Frequency      string `json:"frequency"`
+	Duration       string `json:"duration"`
+}
+
+// CaseHistory records the doctor's notes for an appointment: chief complaint,
+// diagnosis, free-form notes and attachment references.
+type CaseHistory struct {
+	gorm.Model
+	AppointmentID  int    `json:"appointment_id" gorm:"not null;uniqueIndex"`
+	ChiefComplaint string `json:"chief_complaint"`
+	Diagnosis      string `json:"diagnosis"`
+	Notes          string `json:"notes"`
+	Attachments    string `json:"attachments"` // comma-separated file URLs
+}